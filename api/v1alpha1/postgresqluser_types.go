@@ -0,0 +1,95 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+
+// PostgreSQLUser is the Schema for the postgresqlusers API.
+type PostgreSQLUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PostgreSQLUserSpec `json:"spec,omitempty"`
+}
+
+// PostgreSQLUserSpec describes the access a PostgreSQL user should be
+// granted.
+type PostgreSQLUserSpec struct {
+	// Name is the username, excluding any host-specific role prefix.
+	Name string `json:"name"`
+
+	// Read lists the read-only access requests for this user.
+	Read *[]AccessSpec `json:"read,omitempty"`
+
+	// Write lists the read-write access requests for this user.
+	Write *[]WriteAccessSpec `json:"write,omitempty"`
+}
+
+// AccessSpec describes a single schema-level access request.
+type AccessSpec struct {
+	Database string `json:"database"`
+	Schema   string `json:"schema"`
+
+	// Grants lists custom, per-object grants to apply in addition to the
+	// schema-level role. This allows requesting privileges at a finer
+	// granularity than the built-in read/write templates, e.g. EXECUTE on a
+	// single function or SELECT on a single table.
+	Grants []Grant `json:"grants,omitempty"`
+}
+
+// WriteAccessSpec describes a read-write access request for a PostgreSQL
+// user.
+type WriteAccessSpec struct {
+	AccessSpec `json:",inline"`
+}
+
+// Privilege is a PostgreSQL privilege that can be granted on an object.
+type Privilege string
+
+const (
+	PrivilegeSelect  Privilege = "SELECT"
+	PrivilegeInsert  Privilege = "INSERT"
+	PrivilegeUpdate  Privilege = "UPDATE"
+	PrivilegeDelete  Privilege = "DELETE"
+	PrivilegeExecute Privilege = "EXECUTE"
+	PrivilegeUsage   Privilege = "USAGE"
+	PrivilegeAll     Privilege = "ALL"
+)
+
+// ObjectType identifies the kind of PostgreSQL object a Grant targets.
+type ObjectType string
+
+const (
+	ObjectDatabase ObjectType = "DATABASE"
+	ObjectSchema   ObjectType = "SCHEMA"
+	ObjectTable    ObjectType = "TABLE"
+	ObjectSequence ObjectType = "SEQUENCE"
+	ObjectFunction ObjectType = "FUNCTION"
+)
+
+// Grant describes a fine-grained privilege on a single PostgreSQL object, as
+// an alternative to the coarse-grained schema-level read/write model.
+type Grant struct {
+	// Privileges is the set of privileges to grant, e.g. SELECT, INSERT.
+	Privileges []Privilege `json:"privileges"`
+
+	// Object is the kind of object the privileges apply to.
+	Object ObjectType `json:"object"`
+
+	// ObjectName is the name of the object, e.g. a table, sequence or
+	// function name.
+	ObjectName string `json:"objectName"`
+
+	// Database optionally asserts which database the grant applies to, as a
+	// safety check against the enclosing AccessSpec's Database. When empty,
+	// no check is performed. The controller only ever connects to the
+	// enclosing AccessSpec's database, so a value that doesn't match it is
+	// rejected rather than honored.
+	Database string `json:"database,omitempty"`
+
+	// Schema optionally overrides the schema the grant applies to. When
+	// empty, the enclosing AccessSpec's Schema is used.
+	Schema string `json:"schema,omitempty"`
+}
@@ -0,0 +1,42 @@
+package grants
+
+import (
+	"errors"
+
+	"go.lunarway.com/postgresql-controller/pkg/postgres"
+	"go.uber.org/multierr"
+)
+
+// SyncError wraps the error(s) returned by SyncUser together with whether
+// retrying the sync might succeed, so the reconciler can decide whether to
+// requeue with backoff or fail the reconcile permanently.
+type SyncError struct {
+	err       error
+	Retryable bool
+}
+
+func (e *SyncError) Error() string { return e.err.Error() }
+func (e *SyncError) Unwrap() error { return e.err }
+
+// wrapSyncError classifies each error aggregated in err, including errors
+// aggregated beneath wrapping errors, via postgres.ClassifyError, marking the
+// result retryable if any of them are Transient. A nil err returns nil.
+func wrapSyncError(err error) error {
+	if err == nil {
+		return nil
+	}
+	retryable := false
+	pending := []error{err}
+	for len(pending) > 0 && !retryable {
+		e := pending[0]
+		pending = pending[1:]
+		if errs := multierr.Errors(e); len(errs) > 1 {
+			pending = append(pending, errs...)
+		} else if inner := errors.Unwrap(e); inner != nil {
+			pending = append(pending, inner)
+		} else {
+			retryable = postgres.ClassifyError(e) == postgres.Transient
+		}
+	}
+	return &SyncError{err: err, Retryable: retryable}
+}
@@ -3,11 +3,14 @@ package grants
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/go-logr/logr"
 	lunarwayv1alpha1 "go.lunarway.com/postgresql-controller/api/v1alpha1"
 	"go.lunarway.com/postgresql-controller/pkg/postgres"
 	"go.uber.org/multierr"
+	"golang.org/x/sync/errgroup"
 )
 
 // SyncUser syncronizes a PostgreSQL user's access requests against the roles
@@ -30,15 +33,15 @@ func (g *Granter) SyncUser(log logr.Logger, namespace, rolePrefix string, user l
 	accesses, err := g.groupAccesses(log, namespace, read, write)
 	if err != nil {
 		if len(accesses) == 0 {
-			return fmt.Errorf("group accesses: %w", err)
+			return wrapSyncError(fmt.Errorf("group accesses: %w", err))
 		}
 		log.Error(err, "Some access requests could not be resolved. Continuating with the resolved ones")
 	}
 	log.Info(fmt.Sprintf("Found access requests for %d hosts", len(accesses)))
 
-	hosts, err := g.connectToHosts(log, accesses)
+	hosts, err := g.connectToHosts(log, user, accesses)
 	if err != nil {
-		return fmt.Errorf("connect to hosts: %w", err)
+		return wrapSyncError(fmt.Errorf("connect to hosts: %w", err))
 	}
 	defer func() {
 		err := closeConnectionToHosts(hosts)
@@ -47,40 +50,82 @@ func (g *Granter) SyncUser(log logr.Logger, namespace, rolePrefix string, user l
 		}
 	}()
 
-	err = g.setRolesOnHosts(log, prefixedUsername, accesses, hosts)
+	err = g.setRolesOnHosts(log, user, prefixedUsername, accesses, hosts)
 	if err != nil {
-		return fmt.Errorf("grant access on host: %w", err)
+		return wrapSyncError(fmt.Errorf("grant access on host: %w", err))
 	}
 
 	return nil
 }
 
-func (g *Granter) connectToHosts(log logr.Logger, accesses HostAccess) (map[string]*sql.DB, error) {
+// connectToHosts connects to every host in accesses concurrently, bounded
+// by g.hostConcurrency, and returns once all connection attempts have
+// settled. Errors for individual hosts are aggregated deterministically by
+// host name rather than by completion order.
+func (g *Granter) connectToHosts(log logr.Logger, user lunarwayv1alpha1.PostgreSQLUser, accesses HostAccess) (map[string]*sql.DB, error) {
 	hosts := make(map[string]*sql.DB)
-	var errs error
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	connect := g.connectFunc()
+
+	var eg errgroup.Group
+	eg.SetLimit(g.hostConcurrency())
 	for host, access := range accesses {
-		// the zero index is safe as accesses are grouped by access requests so any
-		// host in the map has at least one ReadWriteAccess item
-		database := access[0].Database.Name
-		credentials, ok := g.HostCredentials[host]
-		if !ok {
-			errs = multierr.Append(errs, fmt.Errorf("no credentials for host '%s'", host))
-			continue
-		}
-		connectionString := postgres.ConnectionString{
-			Host:     host,
-			Database: database,
-			User:     credentials.Name,
-			Password: credentials.Password,
-		}
-		db, err := postgres.Connect(log, connectionString)
-		if err != nil {
-			errs = multierr.Append(errs, fmt.Errorf("connect to %s: %w", connectionString, err))
-			continue
-		}
-		hosts[host] = db
+		host, access := host, access
+		eg.Go(func() error {
+			// the zero index is safe as accesses are grouped by access requests so
+			// any host in the map has at least one ReadWriteAccess item
+			database := access[0].Database.Name
+			credentials, ok := g.HostCredentials[host]
+			if !ok {
+				mu.Lock()
+				errs[host] = fmt.Errorf("no credentials for host '%s'", host)
+				mu.Unlock()
+				return nil
+			}
+			connectionString := postgres.ConnectionString{
+				Host:     host,
+				Database: database,
+				User:     credentials.Name,
+				Password: credentials.Password,
+			}
+			db, err := connect(log, connectionString)
+			if err != nil {
+				wrapped := fmt.Errorf("connect to %s: %w", connectionString, err)
+				mu.Lock()
+				errs[host] = wrapped
+				mu.Unlock()
+				g.recordError(user, host, wrapped)
+				return nil
+			}
+			mu.Lock()
+			hosts[host] = db
+			mu.Unlock()
+			return nil
+		})
 	}
-	return hosts, errs
+	// the goroutines above never return an error to eg, so this can't fail
+	_ = eg.Wait()
+
+	return hosts, aggregateErrors(errs)
+}
+
+// aggregateErrors combines errs, keyed by host, into a single multierr
+// error in a deterministic, host-name-sorted order.
+func aggregateErrors(errs map[string]error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	hosts := make([]string, 0, len(errs))
+	for host := range errs {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	var aggregated error
+	for _, host := range hosts {
+		aggregated = multierr.Append(aggregated, errs[host])
+	}
+	return aggregated
 }
 
 func closeConnectionToHosts(hosts map[string]*sql.DB) error {
@@ -94,23 +139,75 @@ func closeConnectionToHosts(hosts map[string]*sql.DB) error {
 	return errs
 }
 
-func (g *Granter) setRolesOnHosts(log logr.Logger, name string, accesses HostAccess, hosts map[string]*sql.DB) error {
-	var errs error
+// setRolesOnHosts applies roles and grants on every host in accesses
+// concurrently, bounded by g.hostConcurrency. Errors for individual hosts
+// are aggregated deterministically by host name rather than by completion
+// order.
+func (g *Granter) setRolesOnHosts(log logr.Logger, user lunarwayv1alpha1.PostgreSQLUser, name string, accesses HostAccess, hosts map[string]*sql.DB) error {
+	errs := make(map[string]error)
+	var mu sync.Mutex
+
+	var eg errgroup.Group
+	eg.SetLimit(g.hostConcurrency())
 	for host, access := range accesses {
-		log = log.WithValues("host", host)
-		connection, ok := hosts[host]
-		if !ok {
-			return fmt.Errorf("connection for host %s not found", host)
-		}
-		err := postgres.Role(log, connection, name, g.StaticRoles, databaseSchemas(access))
-		if err != nil {
-			errs = multierr.Append(errs, fmt.Errorf("grant roles: %w", err))
-		}
+		host, access := host, access
+		eg.Go(func() error {
+			hostLog := log.WithValues("host", host)
+			connection, ok := hosts[host]
+			if !ok {
+				mu.Lock()
+				errs[host] = fmt.Errorf("connection for host %s not found", host)
+				mu.Unlock()
+				return nil
+			}
+			diff, err := postgres.Role(hostLog, connection, name, g.StaticRoles, databaseSchemas(access), g.DryRun)
+			if err != nil {
+				wrapped := fmt.Errorf("grant roles: %w", err)
+				mu.Lock()
+				errs[host] = wrapped
+				mu.Unlock()
+				g.recordError(user, host, wrapped)
+				return nil
+			}
+			g.recordDiff(user, host, diff)
+			return nil
+		})
+	}
+	// the goroutines above never return an error to eg, so this can't fail
+	_ = eg.Wait()
+
+	return aggregateErrors(errs)
+}
+
+// recordDiff emits an event on user describing the role diff computed for
+// host, if a Recorder is configured. Empty diffs are not recorded to avoid
+// spamming the resource's event history on every reconcile.
+func (g *Granter) recordDiff(user lunarwayv1alpha1.PostgreSQLUser, host string, diff postgres.RoleDiff) {
+	if g.Recorder == nil || diff.Empty() {
+		return
 	}
-	if errs != nil {
-		return errs
+	reason := "RolesSynced"
+	if g.DryRun {
+		reason = "RolesSyncedDryRun"
 	}
-	return nil
+	g.Recorder.Eventf(&user, "Normal", reason, "host %s: %s", host, diff.String())
+}
+
+// recordError emits an event on user classifying err, if a Recorder is
+// configured, so operators can see from the resource's events alone whether
+// a failure is worth waiting out or needs intervention. Transient errors are
+// expected to clear on their own on retry, so they're recorded as Normal;
+// every other category needs operator attention and is recorded as Warning.
+func (g *Granter) recordError(user lunarwayv1alpha1.PostgreSQLUser, host string, err error) {
+	if g.Recorder == nil {
+		return
+	}
+	category := postgres.ClassifyError(err)
+	eventType := "Warning"
+	if category == postgres.Transient {
+		eventType = "Normal"
+	}
+	g.Recorder.Eventf(&user, eventType, category.String()+"Error", "host %s: %s", host, err)
 }
 
 func databaseSchemas(accesses []ReadWriteAccess) []postgres.DatabaseSchema {
@@ -120,6 +217,7 @@ func databaseSchemas(accesses []ReadWriteAccess) []postgres.DatabaseSchema {
 			Name:       access.Database.Name,
 			Schema:     access.Database.Schema,
 			Privileges: access.Database.Privileges,
+			Grants:     access.Database.Grants,
 		})
 	}
 	return ds
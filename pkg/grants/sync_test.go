@@ -0,0 +1,98 @@
+package grants
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/stretchr/testify/assert"
+	lunarwayv1alpha1 "go.lunarway.com/postgresql-controller/api/v1alpha1"
+	"go.lunarway.com/postgresql-controller/pkg/postgres"
+	"k8s.io/client-go/tools/record"
+)
+
+// Test_connectToHosts_ConnectsConcurrently asserts that connecting to
+// hostCount hosts takes roughly as long as a single connect, not
+// hostCount connects summed sequentially, proving the host loop is bounded
+// concurrent rather than serial.
+func Test_connectToHosts_ConnectsConcurrently(t *testing.T) {
+	assert := assert.New(t)
+
+	const hostCount = 4
+	const perHostDelay = 50 * time.Millisecond
+
+	accesses := make(HostAccess, hostCount)
+	credentials := make(map[string]Credentials, hostCount)
+	for i := 0; i < hostCount; i++ {
+		host := fmt.Sprintf("host-%d", i)
+		accesses[host] = []ReadWriteAccess{{Database: Database{Name: "db"}}}
+		credentials[host] = Credentials{Name: "user", Password: "pass"}
+	}
+
+	g := &Granter{
+		HostCredentials: credentials,
+		connect: func(logr.Logger, postgres.ConnectionString) (*sql.DB, error) {
+			time.Sleep(perHostDelay)
+			return &sql.DB{}, nil
+		},
+	}
+
+	start := time.Now()
+	hosts, err := g.connectToHosts(logr.Discard(), lunarwayv1alpha1.PostgreSQLUser{}, accesses)
+	elapsed := time.Since(start)
+
+	assert.NoError(err)
+	assert.Len(hosts, hostCount)
+	assert.Less(elapsed, time.Duration(hostCount)*perHostDelay, "hosts should be connected to concurrently, not one at a time")
+}
+
+// Test_recordError_EventTypeFollowsErrorCategory asserts that only
+// Transient errors, which are expected to clear on retry, are recorded as
+// Normal events; every other category needs operator attention and must be
+// recorded as Warning.
+func Test_recordError_EventTypeFollowsErrorCategory(t *testing.T) {
+	cases := []struct {
+		name         string
+		err          error
+		expectedType string
+	}{
+		{
+			name:         "transient is normal",
+			err:          &pgconn.PgError{Code: pgerrcode.AdminShutdown},
+			expectedType: "Normal",
+		},
+		{
+			name:         "permission denied is warning",
+			err:          &pgconn.PgError{Code: pgerrcode.InsufficientPrivilege},
+			expectedType: "Warning",
+		},
+		{
+			name:         "not found is warning",
+			err:          &pgconn.PgError{Code: pgerrcode.UndefinedTable},
+			expectedType: "Warning",
+		},
+		{
+			name:         "fatal is warning",
+			err:          fmt.Errorf("some other failure"),
+			expectedType: "Warning",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			recorder := record.NewFakeRecorder(1)
+			g := &Granter{Recorder: recorder}
+
+			g.recordError(lunarwayv1alpha1.PostgreSQLUser{}, "host-0", c.err)
+
+			event := <-recorder.Events
+			assert.Contains(event, c.expectedType)
+		})
+	}
+}
@@ -1,6 +1,15 @@
 package iam
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxPolicyDocumentBytes is the hard limit AWS enforces on a managed
+// policy's document, in characters of the marshaled JSON.
+const maxPolicyDocumentBytes = 6144
 
 type Policy struct {
 	Name             string
@@ -13,6 +22,12 @@ type PolicyDocument struct {
 	Statement []StatementEntry `json:"Statement,omitempty"`
 }
 
+// NewPolicyDocument returns an empty PolicyDocument for the given IAM
+// policy language version, e.g. "2012-10-17".
+func NewPolicyDocument(version string) *PolicyDocument {
+	return &PolicyDocument{Version: version}
+}
+
 type StatementEntry struct {
 	Effect    string     `json:"Effect,omitempty"`
 	Action    []string   `json:"Action,omitempty"`
@@ -24,14 +39,44 @@ type StringLike struct {
 	StringLike UserID `json:"StringLike,omitempty"`
 }
 
+// UserID is the `aws:userid` StringLike condition value. AWS accepts either
+// a single string or an array of strings here, so AWSUserID marshals as a
+// bare string when it holds exactly one value and as an array otherwise,
+// matching what Compact produces when it merges several users' conditions
+// into one statement.
 type UserID struct {
-	AWSUserID string `json:"aws:userid,omitempty"`
+	AWSUserID []string
+}
+
+func (u UserID) MarshalJSON() ([]byte, error) {
+	if len(u.AWSUserID) == 1 {
+		return json.Marshal(map[string]string{"aws:userid": u.AWSUserID[0]})
+	}
+	return json.Marshal(map[string][]string{"aws:userid": u.AWSUserID})
+}
+
+func (u *UserID) UnmarshalJSON(data []byte) error {
+	var single struct {
+		AWSUserID string `json:"aws:userid"`
+	}
+	if err := json.Unmarshal(data, &single); err == nil && single.AWSUserID != "" {
+		u.AWSUserID = []string{single.AWSUserID}
+		return nil
+	}
+	var multi struct {
+		AWSUserID []string `json:"aws:userid"`
+	}
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return err
+	}
+	u.AWSUserID = multi.AWSUserID
+	return nil
 }
 
 func (p *PolicyDocument) Exists(username string) bool {
 	awsUserID := fmt.Sprintf("*:%s@lunar.app", username)
 	return any(p.Statement, func(s StatementEntry) bool {
-		return s.Condition.StringLike.AWSUserID == awsUserID
+		return containsString(s.Condition.StringLike.AWSUserID, awsUserID)
 	})
 }
 
@@ -45,11 +90,186 @@ func (p *PolicyDocument) Add(region, accountID, rolePrefix, username string) {
 		Effect:    "Allow",
 		Action:    []string{"rds-db:connect"},
 		Resource:  []string{fmt.Sprintf("arn:aws:rds-db:%s:%s:dbuser:*/%s%s", region, accountID, rolePrefix, username)},
-		Condition: StringLike{StringLike: UserID{AWSUserID: awsUserID}},
+		Condition: StringLike{StringLike: UserID{AWSUserID: []string{awsUserID}}},
 	}
 	p.Statement = append(p.Statement, statementEntry)
 }
 
+// Remove drops username's access from the document. username may share a
+// statement with other users, merged there by Compact, in which case only
+// its condition value is dropped; the statement itself is only removed once
+// it has no users left.
+func (p *PolicyDocument) Remove(username string) {
+	awsUserID := fmt.Sprintf("*:%s@lunar.app", username)
+	statements := p.Statement[:0]
+	for _, s := range p.Statement {
+		s.Condition.StringLike.AWSUserID = removeString(s.Condition.StringLike.AWSUserID, awsUserID)
+		if len(s.Condition.StringLike.AWSUserID) == 0 {
+			continue
+		}
+		statements = append(statements, s)
+	}
+	p.Statement = statements
+}
+
+// Compact rewrites the document in place to reduce its marshaled size:
+//
+//   - statements with identical Effect, Action and Resource are merged,
+//     combining their aws:userid condition values into one StringLike list
+//   - statements whose Resource differs only by the username Add appended
+//     after rolePrefix are coalesced into a single statement with a
+//     wildcarded Resource and a merged condition
+//
+// rolePrefix must be the same prefix passed to Add for every statement in
+// the document: it is the boundary between the literal ARN and the
+// variable username, so wildcarding anything before it would grant
+// rds-db:connect as any dbuser on the instance rather than just the ones
+// sharing that role prefix.
+//
+// It returns an error if the document still exceeds maxBytes once
+// compacted, so the caller can fall back to SplitInto.
+func (p *PolicyDocument) Compact(rolePrefix string, maxBytes int) error {
+	p.Statement = mergeIdenticalStatements(p.Statement)
+	p.Statement = coalesceResourceStatements(p.Statement, rolePrefix)
+
+	size, err := p.size()
+	if err != nil {
+		return fmt.Errorf("marshal compacted document: %w", err)
+	}
+	if size > maxBytes {
+		return fmt.Errorf("policy document is %d bytes after compaction, exceeds budget of %d", size, maxBytes)
+	}
+	return nil
+}
+
+// SplitInto partitions the document's statements across as many documents
+// as needed to keep each one's marshaled size at or under maxBytes. A
+// statement is never split: one that alone exceeds maxBytes is placed in a
+// document of its own, which then exceeds maxBytes.
+func (p *PolicyDocument) SplitInto(maxBytes int) []*PolicyDocument {
+	var documents []*PolicyDocument
+	current := NewPolicyDocument(p.Version)
+	for _, statement := range p.Statement {
+		candidate := append(append([]StatementEntry{}, current.Statement...), statement)
+		if len(current.Statement) > 0 {
+			if size, err := (&PolicyDocument{Version: p.Version, Statement: candidate}).size(); err != nil || size > maxBytes {
+				documents = append(documents, current)
+				current = NewPolicyDocument(p.Version)
+				candidate = []StatementEntry{statement}
+			}
+		}
+		current.Statement = candidate
+	}
+	if len(current.Statement) > 0 || len(documents) == 0 {
+		documents = append(documents, current)
+	}
+	return documents
+}
+
+func (p *PolicyDocument) size() (int, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// statementKey groups statements that can be merged outright: same Effect,
+// same Action list and same Resource list.
+func statementKey(s StatementEntry) string {
+	return fmt.Sprintf("%s|%s|%s", s.Effect, strings.Join(s.Action, ","), strings.Join(s.Resource, ","))
+}
+
+func mergeIdenticalStatements(statements []StatementEntry) []StatementEntry {
+	var order []string
+	merged := make(map[string]StatementEntry)
+	for _, s := range statements {
+		key := statementKey(s)
+		existing, ok := merged[key]
+		if !ok {
+			order = append(order, key)
+			merged[key] = s
+			continue
+		}
+		existing.Condition.StringLike.AWSUserID = mergeStrings(
+			existing.Condition.StringLike.AWSUserID,
+			s.Condition.StringLike.AWSUserID,
+		)
+		merged[key] = existing
+	}
+	out := make([]StatementEntry, 0, len(order))
+	for _, key := range order {
+		out = append(out, merged[key])
+	}
+	return out
+}
+
+// resourcePrefix returns the part of an RDS dbuser ARN up to and including
+// rolePrefix, i.e. everything Add placed before the variable username, and
+// whether the resource has that shape at all. The boundary can't be
+// inferred from the ARN alone since Add concatenates rolePrefix and
+// username with no separator between them.
+func resourcePrefix(resource, rolePrefix string) (string, bool) {
+	const marker = ":dbuser:*/"
+	i := strings.Index(resource, marker)
+	if i == -1 {
+		return "", false
+	}
+	rest := resource[i+len(marker):]
+	if !strings.HasPrefix(rest, rolePrefix) {
+		return "", false
+	}
+	return resource[:i+len(marker)] + rolePrefix, true
+}
+
+// coalesceResourceStatements merges statements that share Effect, Action
+// and a single Resource differing only by the username after rolePrefix
+// into one statement with a wildcarded Resource, keeping their conditions
+// intact so access is still restricted to the merged set of users.
+func coalesceResourceStatements(statements []StatementEntry, rolePrefix string) []StatementEntry {
+	type group struct {
+		effect, action, prefix string
+	}
+	var order []group
+	byGroup := make(map[group][]StatementEntry)
+	var rest []StatementEntry
+	for _, s := range statements {
+		if len(s.Resource) != 1 {
+			rest = append(rest, s)
+			continue
+		}
+		prefix, ok := resourcePrefix(s.Resource[0], rolePrefix)
+		if !ok {
+			rest = append(rest, s)
+			continue
+		}
+		key := group{effect: s.Effect, action: strings.Join(s.Action, ","), prefix: prefix}
+		if _, seen := byGroup[key]; !seen {
+			order = append(order, key)
+		}
+		byGroup[key] = append(byGroup[key], s)
+	}
+
+	out := append([]StatementEntry{}, rest...)
+	for _, key := range order {
+		group := byGroup[key]
+		if len(group) == 1 {
+			out = append(out, group[0])
+			continue
+		}
+		merged := group[0]
+		merged.Resource = []string{key.prefix + "*"}
+		for _, s := range group[1:] {
+			merged.Condition.StringLike.AWSUserID = mergeStrings(
+				merged.Condition.StringLike.AWSUserID,
+				s.Condition.StringLike.AWSUserID,
+			)
+		}
+		out = append(out, merged)
+	}
+	return out
+}
+
 func any(vs []StatementEntry, f func(StatementEntry) bool) bool {
 	for _, v := range vs {
 		if f(v) {
@@ -57,4 +277,39 @@ func any(vs []StatementEntry, f func(StatementEntry) bool) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+func containsString(vs []string, v string) bool {
+	for _, s := range vs {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(vs []string, v string) []string {
+	out := vs[:0]
+	for _, s := range vs {
+		if s != v {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func mergeStrings(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var out []string
+	for _, vs := range [][]string{a, b} {
+		for _, v := range vs {
+			if seen[v] {
+				continue
+			}
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
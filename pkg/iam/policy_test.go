@@ -1,6 +1,7 @@
 package iam
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,3 +41,56 @@ func Test_RemoveUsersFromDocument(t *testing.T) {
 	assert.False(document.Exists("user2"))
 	assert.True(document.Exists("user3"))
 }
+
+func Test_CompactMergesUsersIntoWildcardResource(t *testing.T) {
+
+	assert := assert.New(t)
+
+	document := NewPolicyDocument("2012-10-17")
+	document.Add(region, accountID, rolePrefix, "user1")
+	document.Add(region, accountID, rolePrefix, "user2")
+	document.Add(region, accountID, rolePrefix, "user3")
+
+	err := document.Compact(rolePrefix, maxPolicyDocumentBytes)
+
+	assert.NoError(err)
+	assert.Equal(1, document.Count())
+	assert.True(document.Exists("user1"))
+	assert.True(document.Exists("user2"))
+	assert.True(document.Exists("user3"))
+	assert.Equal([]string{fmt.Sprintf("arn:aws:rds-db:%s:%s:dbuser:*/%s", region, accountID, rolePrefix) + "*"}, document.Statement[0].Resource)
+}
+
+func Test_CompactErrorsWhenStillOverBudget(t *testing.T) {
+
+	assert := assert.New(t)
+
+	document := NewPolicyDocument("2012-10-17")
+	document.Add(region, accountID, rolePrefix, "user1")
+
+	err := document.Compact(rolePrefix, 1)
+
+	assert.Error(err)
+}
+
+func Test_SplitIntoPartitionsStatementsUnderByteBudget(t *testing.T) {
+
+	assert := assert.New(t)
+
+	document := NewPolicyDocument("2012-10-17")
+	for i := 0; i < 20; i++ {
+		document.Add(region, accountID, rolePrefix, fmt.Sprintf("user%d", i))
+	}
+
+	documents := document.SplitInto(600)
+
+	assert.True(len(documents) > 1)
+	var total int
+	for _, d := range documents {
+		size, err := d.size()
+		assert.NoError(err)
+		assert.True(size <= 600, "document of size %d exceeds budget", size)
+		total += d.Count()
+	}
+	assert.Equal(document.Count(), total)
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+)
+
+// ErrorCategory classifies a PostgreSQL error by how a caller should react
+// to it: retry, treat as a permanent failure, or something in between.
+type ErrorCategory int
+
+const (
+	// Fatal is returned for errors that carry no actionable information,
+	// including errors that do not unwrap to a *pgconn.PgError at all.
+	// Callers should treat these as permanent.
+	Fatal ErrorCategory = iota
+
+	// Transient errors are expected to succeed on retry, e.g. a dropped
+	// connection or a serialization failure.
+	Transient
+
+	// PermissionDenied means the connected role lacks the privileges
+	// needed to apply a grant or revoke. Retrying will not help without
+	// operator intervention.
+	PermissionDenied
+
+	// NotFound means the statement referenced a role, schema or object
+	// that does not exist.
+	NotFound
+
+	// AlreadyExists means the statement tried to create something that is
+	// already present, e.g. a duplicate role.
+	AlreadyExists
+)
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case Transient:
+		return "Transient"
+	case PermissionDenied:
+		return "PermissionDenied"
+	case NotFound:
+		return "NotFound"
+	case AlreadyExists:
+		return "AlreadyExists"
+	default:
+		return "Fatal"
+	}
+}
+
+// ClassifyError unwraps err to a *pgconn.PgError, if any, and maps its
+// SQLSTATE to an ErrorCategory via pgerrcode. Errors that do not unwrap to a
+// PgError are classified as Fatal, since we have no SQLSTATE to base a
+// retry decision on.
+func ClassifyError(err error) ErrorCategory {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return Fatal
+	}
+	switch pgErr.Code {
+	case pgerrcode.ConnectionException,
+		pgerrcode.ConnectionDoesNotExist,
+		pgerrcode.ConnectionFailure,
+		pgerrcode.SQLClientUnableToEstablishSQLConnection,
+		pgerrcode.SQLServerRejectedEstablishmentOfSQLConnection,
+		pgerrcode.TransactionResolutionUnknown,
+		pgerrcode.SerializationFailure,
+		pgerrcode.DeadlockDetected,
+		pgerrcode.AdminShutdown,
+		pgerrcode.CrashShutdown,
+		pgerrcode.CannotConnectNow,
+		pgerrcode.TooManyConnections:
+		return Transient
+	case pgerrcode.InsufficientPrivilege:
+		return PermissionDenied
+	case pgerrcode.UndefinedObject,
+		pgerrcode.UndefinedTable,
+		pgerrcode.UndefinedColumn,
+		pgerrcode.UndefinedFunction,
+		pgerrcode.InvalidSchemaName,
+		pgerrcode.InvalidCatalogName:
+		return NotFound
+	case pgerrcode.DuplicateObject,
+		pgerrcode.DuplicateSchema,
+		pgerrcode.DuplicateTable,
+		pgerrcode.DuplicateColumn,
+		pgerrcode.DuplicateFunction,
+		pgerrcode.UniqueViolation:
+		return AlreadyExists
+	default:
+		return Fatal
+	}
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ClassifyError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected ErrorCategory
+	}{
+		{
+			name:     "connection refused is transient",
+			err:      &pgconn.PgError{Code: pgerrcode.ConnectionFailure},
+			expected: Transient,
+		},
+		{
+			name:     "serialization failure is transient",
+			err:      &pgconn.PgError{Code: pgerrcode.SerializationFailure},
+			expected: Transient,
+		},
+		{
+			name:     "admin shutdown is transient",
+			err:      &pgconn.PgError{Code: pgerrcode.AdminShutdown},
+			expected: Transient,
+		},
+		{
+			name:     "insufficient privilege is permission denied",
+			err:      &pgconn.PgError{Code: pgerrcode.InsufficientPrivilege},
+			expected: PermissionDenied,
+		},
+		{
+			name:     "undefined table is not found",
+			err:      &pgconn.PgError{Code: pgerrcode.UndefinedTable},
+			expected: NotFound,
+		},
+		{
+			name:     "duplicate object is already exists",
+			err:      &pgconn.PgError{Code: pgerrcode.DuplicateObject},
+			expected: AlreadyExists,
+		},
+		{
+			name:     "syntax error is fatal",
+			err:      &pgconn.PgError{Code: pgerrcode.SyntaxError},
+			expected: Fatal,
+		},
+		{
+			name:     "wrapped pg error is unwrapped before classifying",
+			err:      fmt.Errorf("exec: %w", &pgconn.PgError{Code: pgerrcode.UndefinedTable}),
+			expected: NotFound,
+		},
+		{
+			name:     "non pg error is fatal",
+			err:      fmt.Errorf("some other failure"),
+			expected: Fatal,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, ClassifyError(c.err))
+		})
+	}
+}
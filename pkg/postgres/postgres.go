@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	_ "github.com/lib/pq"
+)
+
+// ConnectionString holds the parameters needed to connect to a PostgreSQL
+// host.
+type ConnectionString struct {
+	Host     string
+	Database string
+	User     string
+	Password string
+}
+
+// String renders the connection string with the password redacted, safe for
+// use in logs and errors.
+func (c ConnectionString) String() string {
+	return fmt.Sprintf("postgres://%s@%s/%s", c.User, c.Host, c.Database)
+}
+
+// Connect opens a connection to the host described by c.
+func Connect(log logr.Logger, c ConnectionString) (*sql.DB, error) {
+	db, err := sql.Open("postgres", fmt.Sprintf(
+		"host=%s dbname=%s user=%s password=%s sslmode=require",
+		c.Host, c.Database, c.User, c.Password,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("opening connection: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("pinging host: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,336 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	lunarwayv1alpha1 "go.lunarway.com/postgresql-controller/api/v1alpha1"
+)
+
+// fakeRows is a fixed set of rows returned for a query matching a
+// fakeConn.queries entry.
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	i       int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
+
+// fakeConn is a minimal driver.Conn that serves canned rows for queries
+// matched by substring, and records every statement it's asked to Exec so
+// tests can assert on the GRANT/REVOKE statements ApplyDiff sends.
+type fakeConn struct {
+	mu      sync.Mutex
+	queries map[string]fakeRows
+	execed  []string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("not implemented") }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for marker, rows := range c.queries {
+		if strings.Contains(query, marker) {
+			out := rows
+			out.i = 0
+			return &out, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.execed = append(c.execed, query)
+	return driver.RowsAffected(0), nil
+}
+
+type fakeDriver struct{ conn *fakeConn }
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) { return d.conn, nil }
+
+// openFake registers conn under a process-wide driver name (database/sql
+// driver registration is global and panics on re-registration) and opens a
+// *sql.DB backed by it.
+func openFake(t *testing.T, conn *fakeConn) *sql.DB {
+	t.Helper()
+	name := fmt.Sprintf("fake-%d", len(sql.Drivers())+1)
+	for _, d := range sql.Drivers() {
+		if d == name {
+			name += "x"
+		}
+	}
+	sql.Register(name, fakeDriver{conn: conn})
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func Test_DiffRole_GrantsMissingStaticRoleAndRevokesStaleOne(t *testing.T) {
+	conn := &fakeConn{
+		queries: map[string]fakeRows{
+			"pg_auth_members": {
+				columns: []string{"rolname"},
+				rows:    [][]driver.Value{{"stale_role"}},
+			},
+		},
+	}
+	db := openFake(t, conn)
+
+	diff, err := DiffRole(db, "alice", []string{"wanted_role"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`GRANT "wanted_role" TO "alice"`}, diff.Grants)
+	assert.Equal(t, []string{`REVOKE "stale_role" FROM "alice"`}, diff.Revokes)
+}
+
+func Test_DiffRole_NoChangesWhenRolesAlreadyMatch(t *testing.T) {
+	conn := &fakeConn{
+		queries: map[string]fakeRows{
+			"pg_auth_members": {
+				columns: []string{"rolname"},
+				rows:    [][]driver.Value{{"wanted_role"}},
+			},
+		},
+	}
+	db := openFake(t, conn)
+
+	diff, err := DiffRole(db, "alice", []string{"wanted_role"}, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty())
+}
+
+func Test_DiffCustomGrants_RevokesTableGrantRemovedFromCR(t *testing.T) {
+	conn := &fakeConn{
+		queries: map[string]fakeRows{
+			"role_table_grants": {
+				columns: []string{"table_name", "privilege_type"},
+				rows:    [][]driver.Value{{"accounts", "SELECT"}},
+			},
+		},
+	}
+	db := openFake(t, conn)
+
+	diff, err := diffCustomGrants(db, "alice", DatabaseSchema{Name: "billing", Schema: "public"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`REVOKE SELECT ON TABLE "public"."accounts" FROM "alice"`}, diff.Revokes)
+	assert.Empty(t, diff.Grants)
+}
+
+func Test_DiffCustomGrants_RevokesStaleGrantsAcrossAllObjectKinds(t *testing.T) {
+	conn := &fakeConn{
+		queries: map[string]fakeRows{
+			"role_table_grants": {columns: []string{"table_name", "privilege_type"}, rows: [][]driver.Value{{"accounts", "SELECT"}}},
+			"pg_class":          {columns: []string{"relname", "privilege_type"}, rows: [][]driver.Value{{"accounts_id_seq", "USAGE"}}},
+			"pg_proc":           {columns: []string{"proname", "privilege_type"}, rows: [][]driver.Value{{"compute_total", "EXECUTE"}}},
+			"pg_namespace":      {columns: []string{"nspname", "privilege_type"}, rows: [][]driver.Value{{"public", "USAGE"}}},
+			"pg_database":       {columns: []string{"datname", "privilege_type"}, rows: [][]driver.Value{{"billing", "CONNECT"}}},
+		},
+	}
+	db := openFake(t, conn)
+
+	diff, err := diffCustomGrants(db, "alice", DatabaseSchema{Name: "billing", Schema: "public"})
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		`REVOKE SELECT ON TABLE "public"."accounts" FROM "alice"`,
+		`REVOKE USAGE ON SEQUENCE "public"."accounts_id_seq" FROM "alice"`,
+		`REVOKE EXECUTE ON FUNCTION "public"."compute_total" FROM "alice"`,
+		`REVOKE USAGE ON SCHEMA "public" FROM "alice"`,
+		`REVOKE CONNECT ON DATABASE "billing" FROM "alice"`,
+	}, diff.Revokes)
+	assert.Empty(t, diff.Grants)
+}
+
+func Test_DiffCustomGrants_GrantsMissingPrivilegeOnRequestedObject(t *testing.T) {
+	conn := &fakeConn{}
+	db := openFake(t, conn)
+
+	schema := DatabaseSchema{
+		Name:   "billing",
+		Schema: "public",
+		Grants: []lunarwayv1alpha1.Grant{
+			{Object: lunarwayv1alpha1.ObjectSequence, ObjectName: "accounts_id_seq", Privileges: []lunarwayv1alpha1.Privilege{lunarwayv1alpha1.PrivilegeUsage}},
+		},
+	}
+
+	diff, err := diffCustomGrants(db, "alice", schema)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`GRANT USAGE ON SEQUENCE "public"."accounts_id_seq" TO "alice"`}, diff.Grants)
+	assert.Empty(t, diff.Revokes)
+}
+
+func Test_DiffCustomGrants_RejectsUnsupportedObjectType(t *testing.T) {
+	conn := &fakeConn{}
+	db := openFake(t, conn)
+
+	schema := DatabaseSchema{
+		Name:   "billing",
+		Schema: "public",
+		Grants: []lunarwayv1alpha1.Grant{
+			{Object: lunarwayv1alpha1.ObjectType("TABLE foo; DROP TABLE users; --"), ObjectName: "accounts", Privileges: []lunarwayv1alpha1.Privilege{lunarwayv1alpha1.PrivilegeSelect}},
+		},
+	}
+
+	_, err := diffCustomGrants(db, "alice", schema)
+
+	assert.Error(t, err)
+}
+
+func Test_DiffCustomGrants_RejectsUnsupportedPrivilege(t *testing.T) {
+	conn := &fakeConn{}
+	db := openFake(t, conn)
+
+	schema := DatabaseSchema{
+		Name:   "billing",
+		Schema: "public",
+		Grants: []lunarwayv1alpha1.Grant{
+			{Object: lunarwayv1alpha1.ObjectTable, ObjectName: "accounts", Privileges: []lunarwayv1alpha1.Privilege{"SELECT; DROP TABLE users; --"}},
+		},
+	}
+
+	_, err := diffCustomGrants(db, "alice", schema)
+
+	assert.Error(t, err)
+}
+
+func Test_DiffCustomGrants_RejectsGrantForAnotherDatabase(t *testing.T) {
+	conn := &fakeConn{}
+	db := openFake(t, conn)
+
+	schema := DatabaseSchema{
+		Name:   "billing",
+		Schema: "public",
+		Grants: []lunarwayv1alpha1.Grant{
+			{Object: lunarwayv1alpha1.ObjectTable, ObjectName: "accounts", Database: "other", Privileges: []lunarwayv1alpha1.Privilege{lunarwayv1alpha1.PrivilegeSelect}},
+		},
+	}
+
+	_, err := diffCustomGrants(db, "alice", schema)
+
+	assert.Error(t, err)
+}
+
+func Test_DiffCustomGrants_AllowsGrantMatchingConnectedDatabase(t *testing.T) {
+	conn := &fakeConn{}
+	db := openFake(t, conn)
+
+	schema := DatabaseSchema{
+		Name:   "billing",
+		Schema: "public",
+		Grants: []lunarwayv1alpha1.Grant{
+			{Object: lunarwayv1alpha1.ObjectTable, ObjectName: "accounts", Database: "billing", Privileges: []lunarwayv1alpha1.Privilege{lunarwayv1alpha1.PrivilegeSelect}},
+		},
+	}
+
+	diff, err := diffCustomGrants(db, "alice", schema)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`GRANT SELECT ON TABLE "public"."accounts" TO "alice"`}, diff.Grants)
+}
+
+func Test_ApplyDiff_GrantsBeforeRevokes(t *testing.T) {
+	conn := &fakeConn{}
+	db := openFake(t, conn)
+
+	diff := RoleDiff{
+		Grants:  []string{"GRANT a TO b"},
+		Revokes: []string{"REVOKE c FROM b"},
+	}
+
+	err := ApplyDiff(db, diff)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GRANT a TO b", "REVOKE c FROM b"}, conn.execed)
+}
+
+func Test_Role_DryRunComputesDiffWithoutExecuting(t *testing.T) {
+	conn := &fakeConn{
+		queries: map[string]fakeRows{
+			"pg_auth_members": {columns: []string{"rolname"}, rows: [][]driver.Value{}},
+		},
+	}
+	db := openFake(t, conn)
+
+	diff, err := Role(logr.Discard(), db, "alice", []string{"wanted_role"}, nil, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`GRANT "wanted_role" TO "alice"`}, diff.Grants)
+	assert.Empty(t, conn.execed)
+}
+
+func Test_QuoteIdent_DoublesEmbeddedQuoteInsteadOfBackslashEscaping(t *testing.T) {
+	assert.Equal(t, `"a"" ; DROP TABLE users; --"`, quoteIdent(`a" ; DROP TABLE users; --`))
+}
+
+func Test_GrantObjectName(t *testing.T) {
+	schema := DatabaseSchema{Schema: "public"}
+
+	cases := []struct {
+		name     string
+		grant    lunarwayv1alpha1.Grant
+		expected string
+	}{
+		{
+			name:     "table falls back to enclosing schema",
+			grant:    lunarwayv1alpha1.Grant{Object: lunarwayv1alpha1.ObjectTable, ObjectName: "accounts"},
+			expected: `"public"."accounts"`,
+		},
+		{
+			name:     "table honours grant schema override",
+			grant:    lunarwayv1alpha1.Grant{Object: lunarwayv1alpha1.ObjectTable, ObjectName: "accounts", Schema: "other"},
+			expected: `"other"."accounts"`,
+		},
+		{
+			name:     "schema object is unqualified",
+			grant:    lunarwayv1alpha1.Grant{Object: lunarwayv1alpha1.ObjectSchema, ObjectName: "public"},
+			expected: `"public"`,
+		},
+		{
+			name:     "database object is unqualified",
+			grant:    lunarwayv1alpha1.Grant{Object: lunarwayv1alpha1.ObjectDatabase, ObjectName: "billing"},
+			expected: `"billing"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, grantObjectName(c.grant, schema))
+		})
+	}
+}
+
+func Test_JoinPrivileges(t *testing.T) {
+	assert.Equal(t, "", joinPrivileges(nil))
+	assert.Equal(t, "SELECT", joinPrivileges([]lunarwayv1alpha1.Privilege{lunarwayv1alpha1.PrivilegeSelect}))
+	assert.Equal(t, "SELECT, INSERT", joinPrivileges([]lunarwayv1alpha1.Privilege{lunarwayv1alpha1.PrivilegeSelect, lunarwayv1alpha1.PrivilegeInsert}))
+}